@@ -0,0 +1,13 @@
+package v1alpha1
+
+// PreHook is a command run before a Jenkins server's job templates are rendered.
+// Its stdout is expected to be a YAML or JSON object which is merged into the
+// template data available to every job template for that server, letting users
+// compute dynamic inputs (e.g. the current Git SHA of a shared library, or a
+// secret resolved from Vault) without forking the jx gitops jenkins jobs command
+type PreHook struct {
+	// Path is the directory the command is run from. If blank it defaults to the current working directory
+	Path string `json:"path,omitempty"`
+	// Cmd is the command line to execute
+	Cmd string `json:"cmd,omitempty"`
+}
@@ -0,0 +1,78 @@
+package v1alpha1
+
+import (
+	"regexp"
+	"strings"
+)
+
+// TemplateRule declares which Jenkins job template to use for repositories matching
+// the given language, build pack and/or repository name glob. Rules are evaluated in
+// order on SourceConfigSpec.JenkinsTemplateFallbacks and the first match wins, letting
+// users declare things like "all Go repos use template X, everything else defaults to Y"
+// without having to set JenkinsJobTemplate on every repository, group or server.
+type TemplateRule struct {
+	// Language is a glob pattern matched against the detected language of the repository,
+	// e.g. "go", "java", "nodejs" or "go*". If blank this rule matches any language
+	Language string `json:"language,omitempty"`
+	// BuildPack is a glob pattern matched against the detected build pack name.
+	// If blank this rule matches any build pack
+	BuildPack string `json:"buildPack,omitempty"`
+	// Repository is a glob pattern matched against the "owner/repo" full name, e.g. "myorg/*"
+	// or just "*" to match every repository. Unlike filepath.Match, "*" here also matches the
+	// "/" between owner and repo, so "*" alone matches any full name. If blank this rule
+	// matches any repository
+	Repository string `json:"repository,omitempty"`
+	// Template is the path to the job template file to use when this rule matches
+	Template string `json:"template,omitempty"`
+}
+
+// Matches returns true if this rule applies to the given language, build pack and full repository name.
+// Language, BuildPack and Repository are all matched as glob patterns so that rules can
+// express things like "go*" as well as exact values
+func (r *TemplateRule) Matches(language, buildPack, fullName string) bool {
+	if !globMatches(r.Language, language) {
+		return false
+	}
+	if !globMatches(r.BuildPack, buildPack) {
+		return false
+	}
+	if !globMatches(r.Repository, fullName) {
+		return false
+	}
+	return true
+}
+
+// globMatches returns true if pattern is blank (matches anything) or value matches the glob
+// pattern. Unlike filepath.Match, "*" matches any sequence of characters including "/", so a
+// pattern like "*" or "myorg/*" matches across the "owner/repo" segments of a repository's
+// full name rather than only within one segment
+func globMatches(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	if pattern == value {
+		return true
+	}
+	re, err := regexp.Compile("^" + globToRegexp(pattern) + "$")
+	if err != nil {
+		return false
+	}
+	return re.MatchString(value)
+}
+
+// globToRegexp translates a glob pattern ("*" and "?" wildcards) into the equivalent regexp,
+// escaping every other rune so literal regexp metacharacters in the pattern are matched as-is
+func globToRegexp(pattern string) string {
+	var b strings.Builder
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,80 @@
+package v1alpha1_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateRuleMatches(t *testing.T) {
+	testCases := []struct {
+		name      string
+		rule      v1alpha1.TemplateRule
+		language  string
+		buildPack string
+		fullName  string
+		want      bool
+	}{
+		{
+			name: "blank rule matches everything",
+			rule: v1alpha1.TemplateRule{},
+			want: true,
+		},
+		{
+			name:     "exact language match",
+			rule:     v1alpha1.TemplateRule{Language: "go"},
+			language: "go",
+			want:     true,
+		},
+		{
+			name:     "language mismatch",
+			rule:     v1alpha1.TemplateRule{Language: "go"},
+			language: "java",
+			want:     false,
+		},
+		{
+			name:     "glob language match",
+			rule:     v1alpha1.TemplateRule{Language: "go*"},
+			language: "golang",
+			want:     true,
+		},
+		{
+			name:      "glob build pack match",
+			rule:      v1alpha1.TemplateRule{BuildPack: "maven-*"},
+			buildPack: "maven-jx",
+			want:      true,
+		},
+		{
+			name:     "glob repository match",
+			rule:     v1alpha1.TemplateRule{Repository: "myorg/*"},
+			fullName: "myorg/myrepo",
+			want:     true,
+		},
+		{
+			name:     "repository mismatch",
+			rule:     v1alpha1.TemplateRule{Repository: "myorg/*"},
+			fullName: "otherorg/myrepo",
+			want:     false,
+		},
+		{
+			name:     "wildcard repository matches across the owner/repo separator",
+			rule:     v1alpha1.TemplateRule{Repository: "*"},
+			fullName: "myorg/myrepo",
+			want:     true,
+		},
+		{
+			name:     "wildcard prefix matches across the owner/repo separator",
+			rule:     v1alpha1.TemplateRule{Repository: "my*repo"},
+			fullName: "myorg/myrepo",
+			want:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.rule.Matches(tc.language, tc.buildPack, tc.fullName)
+			assert.Equal(t, tc.want, got, tc.name)
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package v1alpha1
+
+// TemplateRepository declares a remote source of Jenkins job templates, pinned to a ref,
+// so that an organization can centralize job templates outside each app repo, similar to
+// how JX build-packs are consumed remotely. Repositories declared here can be referenced
+// from JenkinsJobTemplate (or any of its per-repository/group/server overrides) using the
+// shorthand "<name>:<path/to/template.job.gotmpl>"
+type TemplateRepository struct {
+	// Name is the short name used to reference this repository from a job template path
+	Name string `json:"name,omitempty"`
+	// URL is the git clone URL of the template repository. oci:// URLs are rejected when a
+	// template referencing this repository is resolved
+	URL string `json:"url,omitempty"`
+	// Ref is the git ref (tag, branch or commit SHA) to pin to. Defaults to "main" if not specified
+	Ref string `json:"ref,omitempty"`
+}
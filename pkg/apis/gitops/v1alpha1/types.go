@@ -0,0 +1,75 @@
+package v1alpha1
+
+// SourceConfigFileName is the default file name used to store the SourceConfig,
+// found at .jx/gitops/source-config.yaml relative to a cluster git repository
+const SourceConfigFileName = "source-config.yaml"
+
+// SourceConfig represents the source repositories used to create the GitOps environment
+type SourceConfig struct {
+	// Spec the list of groups of repositories
+	Spec SourceConfigSpec `json:"spec,omitempty"`
+}
+
+// SourceConfigSpec defines the repository groups and Jenkins servers used to generate GitOps resources
+type SourceConfigSpec struct {
+	// JenkinsServers the jenkins servers and their associated repository groups
+	JenkinsServers []JenkinsServer `json:"jenkinsServers,omitempty"`
+	// JenkinsJobTemplate the default template file used to generate the Jenkins Job DSL for a
+	// repository if none is specified at the repository, group or server level
+	JenkinsJobTemplate string `json:"jenkinsJobTemplate,omitempty"`
+	// JenkinsTemplateFallbacks is an ordered list of rules used to resolve a job template for a
+	// repository when none of the repository, group, server or JenkinsJobTemplate settings are used
+	JenkinsTemplateFallbacks []TemplateRule `json:"jenkinsTemplateFallbacks,omitempty"`
+	// JenkinsTemplateRepositories declares remote git repositories of job templates, pinned to a
+	// ref, that can be referenced by name from any JenkinsJobTemplate setting
+	JenkinsTemplateRepositories []TemplateRepository `json:"jenkinsTemplateRepositories,omitempty"`
+}
+
+// JenkinsServer represents a single jenkins server and the repository groups associated with it
+type JenkinsServer struct {
+	// Server the name of the jenkins server
+	Server string `json:"server,omitempty"`
+	// JobTemplate the template file used for repositories in this server if not overridden at the
+	// repository or group level
+	JobTemplate string `json:"jobTemplate,omitempty"`
+	// Groups the repository groups associated with this jenkins server
+	Groups []RepositoryGroup `json:"groups,omitempty"`
+	// PreHooks are commands run before templates are rendered for this server, whose stdout
+	// (expected to be a YAML or JSON object) is merged into the template data
+	PreHooks []PreHook `json:"preHooks,omitempty"`
+	// ValuesFrom is a list of YAML files whose contents are merged into the template data for this server
+	ValuesFrom []string `json:"valuesFrom,omitempty"`
+}
+
+// RepositoryGroup represents a group of repositories owned by the same owner/organisation on the same git provider
+type RepositoryGroup struct {
+	// Owner the name of the organisation or user that owns the repositories in this group
+	Owner string `json:"owner,omitempty"`
+	// Provider the URL of the git provider
+	Provider string `json:"provider,omitempty"`
+	// ProviderKind the kind of the git provider, e.g. github, gitlab, bitbucketserver
+	ProviderKind string `json:"providerKind,omitempty"`
+	// ProviderName the name of the git provider
+	ProviderName string `json:"providerName,omitempty"`
+	// JenkinsJobTemplate the template file used for repositories in this group if not overridden
+	// at the repository level
+	JenkinsJobTemplate string `json:"jenkinsJobTemplate,omitempty"`
+	// Repositories the repositories in this group
+	Repositories []Repository `json:"repositories,omitempty"`
+}
+
+// Repository represents a single git repository to generate GitOps resources for
+type Repository struct {
+	// Name the name of the repository
+	Name string `json:"name,omitempty"`
+	// URL the URL of the repository
+	URL string `json:"url,omitempty"`
+	// HTTPCloneURL the HTTP clone URL of the repository
+	HTTPCloneURL string `json:"httpCloneURL,omitempty"`
+	// JenkinsJobTemplate the template file used to generate the Jenkins Job DSL for this repository
+	JenkinsJobTemplate string `json:"jenkinsJobTemplate,omitempty"`
+	// Language is the detected (or cached) primary language of the repository, e.g. "go", "java", "nodejs"
+	Language string `json:"language,omitempty"`
+	// BuildPack is the detected (or cached) JX build pack name for the repository
+	BuildPack string `json:"buildPack,omitempty"`
+}
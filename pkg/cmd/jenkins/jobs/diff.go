@@ -0,0 +1,212 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/Masterminds/sprig"
+	"github.com/jenkins-x/jx-gitops/pkg/cmd/jenkins/jobs/format"
+	"github.com/jenkins-x/jx-gitops/pkg/jenkinsclient"
+	"github.com/jenkins-x/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/templater"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffLong = templates.LongDesc(`
+		Shows the drift between the GitOps rendered Jenkins job config and what is actually running on the live Jenkins servers
+`)
+
+	diffExample = templates.Examples(`
+		# show the drift between the rendered jobs and the live jenkins servers
+		%s jenkins jobs diff
+
+		# fail the command (e.g. for a PR check) if any drift is found
+		%s jenkins jobs diff --fail-on-drift
+	`)
+)
+
+// DiffOptions the options for the diff command
+type DiffOptions struct {
+	Options
+	FailOnDrift bool
+	OutFile     string
+	Report      DriftReport
+}
+
+// DriftReport is the structured diff report comparing the rendered job config against the live servers
+type DriftReport struct {
+	Servers []ServerDriftReport `json:"servers"`
+}
+
+// ServerDriftReport captures drift for a single jenkins server
+type ServerDriftReport struct {
+	Server string           `json:"server"`
+	Jobs   []JobDriftReport `json:"jobs"`
+}
+
+// JobDriftReport captures whether a single repo's job has drifted from the rendered template
+type JobDriftReport struct {
+	Repository string `json:"repository"`
+	Drifted    bool   `json:"drifted"`
+	Diff       string `json:"diff,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// NewCmdJenkinsJobsDiff creates a command object for the diff command
+func NewCmdJenkinsJobsDiff() (*cobra.Command, *DiffOptions) {
+	o := &DiffOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "diff",
+		Short:   "Shows the drift between the GitOps rendered Jenkins jobs and the live Jenkins servers",
+		Long:    diffLong,
+		Example: fmt.Sprintf(diffExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the current working directory")
+	cmd.Flags().StringVarP(&o.ConfigFile, "config", "c", "", "the configuration file to load for the repository configurations. If not specified we look in ./.jx/gitops/source-config.yaml")
+	cmd.Flags().StringVarP(&o.DefaultTemplate, "default-template", "", "", "the default job template file if none is configured for a repository")
+	cmd.Flags().StringVarP(&o.JenkinsServersFile, "jenkins-servers-file", "", "", "the kubeconfig-style file of jenkins server URLs and credentials. Defaults to ~/.jx/jenkins-servers.yaml")
+	cmd.Flags().StringVarP(&o.Format, "format", "", "xml", "the output format to diff the live server job config.xml against: xml or multibranch. Job DSL/CasC script formats have no equivalent config.xml on the server so cannot be diffed")
+	cmd.Flags().StringVarP(&o.OutFile, "out", "o", "", "the file to write the JSON drift report to. If not specified the report is only printed as text")
+	cmd.Flags().BoolVarP(&o.FailOnDrift, "fail-on-drift", "", false, "return an error if any repository has drifted, so this can gate a PR check")
+	return cmd, o
+}
+
+func (o *DiffOptions) Run() error {
+	if o.Format != "xml" && o.Format != "multibranch" {
+		return errors.Errorf("diff only supports --format xml or multibranch (got %q): a live Jenkins server's config.xml has no equivalent representation in the jobdsl/casc-jobs Groovy formats, so there is nothing to diff against", o.Format)
+	}
+
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate options")
+	}
+
+	err = o.renderTemplates()
+	if err != nil {
+		return errors.Wrapf(err, "failed to render jenkins job templates")
+	}
+
+	formatter, err := format.NewFormatter(o.Format)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create formatter for format %s", o.Format)
+	}
+
+	serversConfig, err := jenkinsclient.LoadServersConfig(o.JenkinsServersFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load jenkins servers file")
+	}
+
+	dmp := diffmatchpatch.New()
+	drifted := false
+
+	for server, configs := range o.JenkinsServerTemplates {
+		serverReport := ServerDriftReport{Server: server}
+
+		serverConfig := serversConfig.FindServer(server)
+		if serverConfig == nil {
+			log.Logger().Warnf("no jenkins server %s configured in the jenkins-servers.yaml file so cannot diff its jobs", server)
+			o.Report.Servers = append(o.Report.Servers, serverReport)
+			continue
+		}
+		client := jenkinsclient.NewClient(*serverConfig)
+
+		renderedFiles, err := o.renderJobFiles(server, configs, formatter)
+		if err != nil {
+			return errors.Wrapf(err, "failed to format jobs for server %s", server)
+		}
+
+		for _, jcfg := range configs {
+			jobReport := JobDriftReport{Repository: jcfg.Key}
+
+			rendered, ok := renderedFiles[filepath.Join("jobs", jcfg.Key, "config.xml")]
+			if !ok {
+				jobReport.Error = "no rendered config.xml produced for this job"
+				serverReport.Jobs = append(serverReport.Jobs, jobReport)
+				continue
+			}
+
+			live, err := client.FetchJobConfigXML(jcfg.Key)
+			if err != nil {
+				jobReport.Error = err.Error()
+				serverReport.Jobs = append(serverReport.Jobs, jobReport)
+				continue
+			}
+
+			if live != rendered {
+				jobReport.Drifted = true
+				diffs := dmp.DiffMain(live, rendered, false)
+				jobReport.Diff = dmp.DiffPrettyText(diffs)
+				drifted = true
+			}
+			serverReport.Jobs = append(serverReport.Jobs, jobReport)
+		}
+		o.Report.Servers = append(o.Report.Servers, serverReport)
+	}
+
+	err = o.printReport()
+	if err != nil {
+		return errors.Wrapf(err, "failed to print drift report")
+	}
+
+	if drifted && o.FailOnDrift {
+		return errors.Errorf("jenkins job configuration has drifted from the GitOps source, see report for details")
+	}
+	return nil
+}
+
+// renderJobFiles evaluates every job template for the server and runs them through the
+// selected formatter, returning the same filename -> content map Run() would write to disk,
+// so the diff compares exactly what GitOps would actually produce
+func (o *DiffOptions) renderJobFiles(server string, configs []*JenkinsTemplateConfig, formatter format.Formatter) (map[string]string, error) {
+	funcMap := sprig.TxtFuncMap()
+
+	jobs := make([]format.Job, 0, len(configs))
+	for _, jcfg := range configs {
+		output, err := templater.Evaluate(funcMap, jcfg.TemplateData, jcfg.TemplateText, jcfg.TemplateFile, "Jenkins Server "+server)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to evaluate template %s", jcfg.TemplateFile)
+		}
+		jobs = append(jobs, format.Job{Key: jcfg.Key, CloneURL: jcfg.CloneURL, TemplateFile: jcfg.TemplateFile, Output: output})
+	}
+	return formatter.Format(server, jobs)
+}
+
+func (o *DiffOptions) printReport() error {
+	for _, server := range o.Report.Servers {
+		for _, job := range server.Jobs {
+			switch {
+			case job.Error != "":
+				log.Logger().Warnf("server %s repository %s: failed to fetch live config: %s", server.Server, job.Repository, job.Error)
+			case job.Drifted:
+				log.Logger().Infof("server %s repository %s: DRIFTED", server.Server, job.Repository)
+			default:
+				log.Logger().Infof("server %s repository %s: up to date", server.Server, job.Repository)
+			}
+		}
+	}
+
+	if o.OutFile == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(&o.Report, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal drift report")
+	}
+
+	return ioutil.WriteFile(o.OutFile, data, files.DefaultFileWritePermissions)
+}
@@ -0,0 +1,29 @@
+package format
+
+import "strings"
+
+var cascJobsHeader = `# NOTE this file is autogenerated - DO NOT EDIT!
+#
+# This file is generated from the template files via the command:
+#    jx gitops jenkins jobs --format casc-jobs
+jobs:
+`
+
+// CascJobsFormatter emits the newer top-level `+"`jobs:`"+` CasC key supported by recent
+// configuration-as-code-plugin releases, instead of nesting the Job DSL inside
+// controller.JCasC.configScripts.jxsetup
+type CascJobsFormatter struct{}
+
+func (*CascJobsFormatter) Format(server string, jobs []Job) (map[string]string, error) {
+	buf := strings.Builder{}
+	buf.WriteString(cascJobsHeader)
+
+	for _, job := range jobs {
+		buf.WriteString("  - script: |\n")
+		buf.WriteString("      # from template: " + job.TemplateFile + "\n")
+		buf.WriteString(indentText(job.Output, "      "))
+		buf.WriteString("\n")
+	}
+
+	return map[string]string{"job-values.yaml": buf.String()}, nil
+}
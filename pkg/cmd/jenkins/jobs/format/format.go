@@ -0,0 +1,50 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Job is the rendered Job DSL/groovy output for a single repository, ready to be
+// written out in whichever output format the user has chosen
+type Job struct {
+	// Key is the "owner/repo" full name of the repository the job is for
+	Key string
+	// CloneURL is the HTTP clone URL of the repository, used by formats that need to tell
+	// Jenkins where to clone the source from (e.g. multibranch)
+	CloneURL string
+	// TemplateFile is the path to the template file the job was rendered from, used for comments/errors
+	TemplateFile string
+	// Output is the rendered Job DSL/groovy script for the repository
+	Output string
+}
+
+// Formatter converts the rendered jobs for a Jenkins server into one or more output files.
+// The returned map is keyed by the file path (relative to the server's output directory)
+// to its contents, letting formats like multibranch emit one file per repository
+type Formatter interface {
+	Format(server string, jobs []Job) (map[string]string, error)
+}
+
+// NewFormatter returns the Formatter for the given --format flag value.
+// Supported formats are: jobdsl (default), casc-jobs, multibranch, xml
+func NewFormatter(format string) (Formatter, error) {
+	switch format {
+	case "", "jobdsl":
+		return &JobDSLFormatter{}, nil
+	case "casc-jobs":
+		return &CascJobsFormatter{}, nil
+	case "multibranch":
+		return &MultibranchFormatter{}, nil
+	case "xml":
+		return &XMLFormatter{}, nil
+	default:
+		return nil, errors.Errorf("unsupported format %q, must be one of: jobdsl, casc-jobs, multibranch, xml", format)
+	}
+}
+
+func indentText(text string, indent string) string {
+	lines := strings.Split(text, "\n")
+	return indent + strings.Join(lines, "\n"+indent)
+}
@@ -0,0 +1,102 @@
+package format_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx-gitops/pkg/cmd/jenkins/jobs/format"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFormatterUnknown(t *testing.T) {
+	_, err := format.NewFormatter("bogus")
+	assert.Error(t, err)
+}
+
+func TestJobDSLFormatter(t *testing.T) {
+	formatter, err := format.NewFormatter("jobdsl")
+	require.NoError(t, err)
+
+	files, err := formatter.Format("myserver", []format.Job{
+		{Key: "myorg/myrepo", TemplateFile: "job.gotmpl", Output: "job('myorg/myrepo') {}"},
+	})
+	require.NoError(t, err)
+	require.Contains(t, files, "job-values.yaml")
+	assert.Contains(t, files["job-values.yaml"], "configScripts")
+	assert.Contains(t, files["job-values.yaml"], "job('myorg/myrepo') {}")
+}
+
+func TestCascJobsFormatter(t *testing.T) {
+	formatter, err := format.NewFormatter("casc-jobs")
+	require.NoError(t, err)
+
+	files, err := formatter.Format("myserver", []format.Job{
+		{Key: "myorg/myrepo", TemplateFile: "job.gotmpl", Output: "job('myorg/myrepo') {}"},
+	})
+	require.NoError(t, err)
+	require.Contains(t, files, "job-values.yaml")
+	assert.NotContains(t, files["job-values.yaml"], "configScripts")
+	assert.Contains(t, files["job-values.yaml"], "jobs:")
+}
+
+func TestMultibranchFormatterRequiresCloneURL(t *testing.T) {
+	formatter, err := format.NewFormatter("multibranch")
+	require.NoError(t, err)
+
+	_, err = formatter.Format("myserver", []format.Job{{Key: "myorg/myrepo"}})
+	assert.Error(t, err, "expected an error when CloneURL is missing")
+}
+
+func TestMultibranchFormatter(t *testing.T) {
+	formatter, err := format.NewFormatter("multibranch")
+	require.NoError(t, err)
+
+	files, err := formatter.Format("myserver", []format.Job{
+		{Key: "myorg/myrepo", CloneURL: "https://github.com/myorg/myrepo.git"},
+	})
+	require.NoError(t, err)
+	require.Contains(t, files, "jobs/myorg/myrepo/config.xml")
+	assert.Contains(t, files["jobs/myorg/myrepo/config.xml"], "https://github.com/myorg/myrepo.git")
+	assert.Contains(t, files["jobs/myorg/myrepo/config.xml"], "WorkflowMultiBranchProject")
+}
+
+func TestMultibranchFormatterDistinctOwners(t *testing.T) {
+	formatter, err := format.NewFormatter("multibranch")
+	require.NoError(t, err)
+
+	files, err := formatter.Format("myserver", []format.Job{
+		{Key: "org-a/api", CloneURL: "https://github.com/org-a/api.git"},
+		{Key: "org-b/api", CloneURL: "https://github.com/org-b/api.git"},
+	})
+	require.NoError(t, err)
+	require.Contains(t, files, "jobs/org-a/api/config.xml")
+	require.Contains(t, files, "jobs/org-b/api/config.xml")
+	assert.Contains(t, files["jobs/org-a/api/config.xml"], "org-a/api.git")
+	assert.Contains(t, files["jobs/org-b/api/config.xml"], "org-b/api.git")
+}
+
+func TestXMLFormatter(t *testing.T) {
+	formatter, err := format.NewFormatter("xml")
+	require.NoError(t, err)
+
+	files, err := formatter.Format("myserver", []format.Job{
+		{Key: "myorg/myrepo", Output: "job('myorg/myrepo') {}"},
+	})
+	require.NoError(t, err)
+	require.Contains(t, files, "jobs/myorg/myrepo/config.xml")
+	assert.Contains(t, files["jobs/myorg/myrepo/config.xml"], "job(&#39;myorg/myrepo&#39;) {}")
+	assert.Contains(t, files["jobs/myorg/myrepo/config.xml"], "ExecuteDslScripts")
+}
+
+func TestXMLFormatterDistinctOwners(t *testing.T) {
+	formatter, err := format.NewFormatter("xml")
+	require.NoError(t, err)
+
+	files, err := formatter.Format("myserver", []format.Job{
+		{Key: "org-a/api", Output: "job('org-a/api') {}"},
+		{Key: "org-b/api", Output: "job('org-b/api') {}"},
+	})
+	require.NoError(t, err)
+	require.Contains(t, files, "jobs/org-a/api/config.xml")
+	require.Contains(t, files, "jobs/org-b/api/config.xml")
+}
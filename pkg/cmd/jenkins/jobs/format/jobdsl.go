@@ -0,0 +1,34 @@
+package format
+
+import "strings"
+
+const jobDSLIndent = "              "
+
+var jobDSLHeader = `# NOTE this file is autogenerated - DO NOT EDIT!
+#
+# This file is generated from the template files via the command:
+#    jx gitops jenkins jobs
+controller:
+  JCasC:
+    configScripts:
+      jxsetup: |
+        jobs:
+          - script: |
+`
+
+// JobDSLFormatter is the original/default format: it wraps every repository's Job DSL
+// script inside a single CasC configScripts.jxsetup block in job-values.yaml
+type JobDSLFormatter struct{}
+
+func (*JobDSLFormatter) Format(server string, jobs []Job) (map[string]string, error) {
+	buf := strings.Builder{}
+	buf.WriteString(jobDSLHeader)
+
+	for _, job := range jobs {
+		buf.WriteString(jobDSLIndent + "// from template: " + job.TemplateFile + "\n")
+		buf.WriteString(indentText(job.Output, jobDSLIndent))
+		buf.WriteString(jobDSLIndent + "\n")
+	}
+
+	return map[string]string{"job-values.yaml": buf.String()}, nil
+}
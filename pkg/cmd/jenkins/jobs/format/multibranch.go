@@ -0,0 +1,47 @@
+package format
+
+import (
+	"fmt"
+	"html"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+const multibranchTemplate = `<?xml version='1.1' encoding='UTF-8'?>
+<!-- NOTE this file is autogenerated - DO NOT EDIT! -->
+<org.jenkinsci.plugins.workflow.multibranch.WorkflowMultiBranchProject plugin="workflow-multibranch">
+  <description>%s</description>
+  <sources class="jenkins.branch.MultiBranchProject$BranchSourceList">
+    <data>
+      <jenkins.branch.BranchSource>
+        <source class="jenkins.plugins.git.GitSCMSource">
+          <id>%s</id>
+          <remote>%s</remote>
+        </source>
+      </jenkins.branch.BranchSource>
+    </data>
+  </sources>
+  <factory class="org.jenkinsci.plugins.workflow.multibranch.WorkflowBranchProjectFactory">
+    <scriptPath>Jenkinsfile</scriptPath>
+  </factory>
+</org.jenkinsci.plugins.workflow.multibranch.WorkflowMultiBranchProject>
+`
+
+// MultibranchFormatter emits one WorkflowMultiBranchProject config.xml per repository,
+// laid out the way Jenkins expects to find it at $JENKINS_HOME/jobs/<name>/config.xml
+type MultibranchFormatter struct{}
+
+func (*MultibranchFormatter) Format(server string, jobs []Job) (map[string]string, error) {
+	files := map[string]string{}
+	for _, job := range jobs {
+		remote := job.CloneURL
+		if remote == "" {
+			return nil, errors.Errorf("cannot generate a multibranch config.xml for %s as it has no CloneURL", job.Key)
+		}
+		name := filepath.Base(job.Key)
+		xml := fmt.Sprintf(multibranchTemplate, html.EscapeString(job.Key), html.EscapeString(name), html.EscapeString(remote))
+		files[filepath.Join("jobs", job.Key, "config.xml")] = xml
+	}
+	return files, nil
+}
@@ -0,0 +1,40 @@
+package format
+
+import (
+	"fmt"
+	"html"
+	"path/filepath"
+)
+
+const xmlJobTemplate = `<?xml version='1.1' encoding='UTF-8'?>
+<!-- NOTE this file is autogenerated - DO NOT EDIT! -->
+<project>
+  <description>%s</description>
+  <builders>
+    <javaposse.jobdsl.plugin.ExecuteDslScripts plugin="job-dsl">
+      <scriptText>%s</scriptText>
+      <usingScriptText>true</usingScriptText>
+      <sandbox>true</sandbox>
+      <removedJobAction>IGNORE</removedJobAction>
+      <removedViewAction>IGNORE</removedViewAction>
+    </javaposse.jobdsl.plugin.ExecuteDslScripts>
+  </builders>
+  <disabled>false</disabled>
+</project>
+`
+
+// XMLFormatter emits one Job-DSL seed job config.xml per repository: a freestyle project
+// whose single build step runs the rendered Job DSL script via the job-dsl plugin's
+// ExecuteDslScripts builder, for teams that want plain XML job definitions rather than a
+// CasC Groovy block. The rendered Output is Job DSL syntax, not a Groovy pipeline script,
+// so it must be run through ExecuteDslScripts rather than embedded in a CpsFlowDefinition
+type XMLFormatter struct{}
+
+func (*XMLFormatter) Format(server string, jobs []Job) (map[string]string, error) {
+	files := map[string]string{}
+	for _, job := range jobs {
+		xml := fmt.Sprintf(xmlJobTemplate, html.EscapeString(job.Key), html.EscapeString(job.Output))
+		files[filepath.Join("jobs", job.Key, "config.xml")] = xml
+	}
+	return files, nil
+}
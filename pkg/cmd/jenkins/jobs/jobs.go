@@ -1,16 +1,22 @@
 package jobs
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/sprig"
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
 	"github.com/jenkins-x/jx-gitops/pkg/cmd/jenkins/add"
+	"github.com/jenkins-x/jx-gitops/pkg/cmd/jenkins/jobs/format"
+	"github.com/jenkins-x/jx-gitops/pkg/jenkinsclient"
+	"github.com/jenkins-x/jx-gitops/pkg/jenkinstemplates"
 	"github.com/jenkins-x/jx-gitops/pkg/rootcmd"
 	"github.com/jenkins-x/jx-gitops/pkg/sourceconfigs"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
@@ -22,6 +28,7 @@ import (
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
 )
 
 var (
@@ -37,19 +44,6 @@ var (
 
 	`)
 
-	jobValuesHeader = `# NOTE this file is autogenerated - DO NOT EDIT!
-#
-# This file is generated from the template files via the command: 
-#    jx gitops jenkins jobs
-controller:
-  JCasC:
-    configScripts:
-      jxsetup: |
-        jobs:
-          - script: |
-`
-	indent = "              "
-
 	sampleValuesFile = `# custom Jenkins chart configuration
 # see https://github.com/jenkinsci/helm-charts/blob/main/charts/jenkins/VALUES_SUMMARY.md
 
@@ -64,14 +58,20 @@ type Options struct {
 	OutDir                 string
 	DefaultTemplate        string
 	NoCreateHelmfile       bool
+	Apply                  bool
+	JenkinsServersFile     string
+	Format                 string
+	DetectLanguage         bool
 	SourceConfig           v1alpha1.SourceConfig
 	JenkinsServerTemplates map[string][]*JenkinsTemplateConfig
+	Resolver               *jenkinstemplates.Resolver
 }
 
 // JenkinsTemplateConfig stores the data to render jenkins config files
 type JenkinsTemplateConfig struct {
 	Server       string
 	Key          string
+	CloneURL     string
 	TemplateFile string
 	TemplateText string
 	TemplateData map[string]interface{}
@@ -97,6 +97,13 @@ func NewCmdJenkinsJobs() (*cobra.Command, *Options) {
 	cmd.Flags().StringVarP(&o.ConfigFile, "config", "c", "", "the configuration file to load for the repository configurations. If not specified we look in ./.jx/gitops/source-config.yaml")
 	cmd.Flags().StringVarP(&o.DefaultTemplate, "default-template", "", "", "the default job template file if none is configured for a repository")
 	cmd.Flags().BoolVarP(&o.NoCreateHelmfile, "no-create-helmfile", "", false, "disables the creation of the helmfiles/jenkinsName/helmfile.yaml file if a jenkins server does not yet exist")
+	cmd.Flags().BoolVarP(&o.Apply, "apply", "", false, "applies the generated Job DSL directly to the live Jenkins servers via their scriptText API instead of waiting for the helmfile roll-out")
+	cmd.Flags().StringVarP(&o.JenkinsServersFile, "jenkins-servers-file", "", "", "the kubeconfig-style file of jenkins server URLs and credentials used with --apply. Defaults to ~/.jx/jenkins-servers.yaml")
+	cmd.Flags().StringVarP(&o.Format, "format", "", "jobdsl", "the output format to generate: jobdsl, casc-jobs, multibranch or xml")
+	cmd.Flags().BoolVarP(&o.DetectLanguage, "detect-language", "", false, "shallow clone repositories with no cached language/buildPack to detect them from their build files. Off by default as it performs a network git clone per repository")
+
+	diffCmd, _ := NewCmdJenkinsJobsDiff()
+	cmd.AddCommand(diffCmd)
 	return cmd, o
 }
 
@@ -107,6 +114,13 @@ func (o *Options) Validate() error {
 	if o.OutDir == "" {
 		o.OutDir = filepath.Join(o.Dir, "helmfiles")
 	}
+	if o.Resolver == nil {
+		resolver, err := jenkinstemplates.NewResolver(o.Dir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create job template resolver")
+		}
+		o.Resolver = resolver
+	}
 
 	exists, err := files.FileExists(o.ConfigFile)
 	if err != nil {
@@ -138,12 +152,12 @@ func (o *Options) Validate() error {
 	return nil
 }
 
-func (o *Options) Run() error {
-	err := o.Validate()
-	if err != nil {
-		return errors.Wrapf(err, "failed to validate options")
-	}
-
+// renderTemplates walks the SourceConfig and populates o.JenkinsServerTemplates with the
+// Job DSL template for every repository, ready to be written out or applied/diffed
+func (o *Options) renderTemplates() error {
+	// the JenkinsJobTemplate as configured, before it is defaulted below: the fallback search
+	// path already ends with jenkins/templates/default.job.gotmpl
+	configuredJobTemplate := o.SourceConfig.Spec.JenkinsJobTemplate
 	config := &o.SourceConfig
 	if config.Spec.JenkinsJobTemplate == "" {
 		relPath := filepath.Join("jenkins", "templates", "default.job.gotmpl")
@@ -159,6 +173,12 @@ func (o *Options) Run() error {
 
 	for i := range config.Spec.JenkinsServers {
 		server := &config.Spec.JenkinsServers[i]
+
+		extraData, err := o.serverTemplateExtras(server)
+		if err != nil {
+			return errors.Wrapf(err, "failed to compute template data for jenkins server %s", server.Server)
+		}
+
 		for j := range server.Groups {
 			group := &server.Groups[j]
 			for k := range group.Repositories {
@@ -166,13 +186,38 @@ func (o *Options) Run() error {
 				sourceconfigs.DefaultValues(config, group, repo)
 				serverName := server.Server
 				jobTemplate := firstNonBlankValue(repo.JenkinsJobTemplate, group.JenkinsJobTemplate, server.JobTemplate, config.Spec.JenkinsJobTemplate)
-				err = o.processJenkinsConfig(group, repo, serverName, jobTemplate)
+				if firstNonBlankValue(repo.JenkinsJobTemplate, group.JenkinsJobTemplate, server.JobTemplate, configuredJobTemplate) == "" {
+					jobTemplate = o.fallbackJobTemplate(config, group, repo)
+				}
+				err := o.processJenkinsConfig(group, repo, serverName, jobTemplate, extraData)
 				if err != nil {
 					return errors.Wrapf(err, "failed to process Jenkins Config")
 				}
 			}
 		}
 	}
+	return nil
+}
+
+func (o *Options) Run() error {
+	if o.Apply && o.Format != "" && o.Format != "jobdsl" {
+		return errors.Errorf("--apply is only supported with --format jobdsl: the scriptText API expects a Job DSL groovy script, not %s output", o.Format)
+	}
+
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate options")
+	}
+
+	err = o.renderTemplates()
+	if err != nil {
+		return errors.Wrapf(err, "failed to render jenkins job templates")
+	}
+
+	formatter, err := format.NewFormatter(o.Format)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create formatter for format %s", o.Format)
+	}
 
 	for server, configs := range o.JenkinsServerTemplates {
 		dir := filepath.Join(o.OutDir, server)
@@ -181,41 +226,82 @@ func (o *Options) Run() error {
 			return errors.Wrapf(err, "failed to create dir %s", dir)
 		}
 
-		err = o.verifyServerHelmfileExists(dir, server)
-		if err != nil {
-			return errors.Wrapf(err, "failed to verify the jenkins helmfile exists for %s", server)
+		if o.Format == "" || o.Format == "jobdsl" || o.Format == "casc-jobs" {
+			err = o.verifyServerHelmfileExists(dir, server)
+			if err != nil {
+				return errors.Wrapf(err, "failed to verify the jenkins helmfile exists for %s", server)
+			}
 		}
 
-		path := filepath.Join(dir, "job-values.yaml")
-		log.Logger().Infof("creating Jenkins values.yaml file %s", path)
-
 		funcMap := sprig.TxtFuncMap()
 
-		buf := strings.Builder{}
-		buf.WriteString(jobValuesHeader)
-
+		outputs := map[string]string{}
+		jobs := make([]format.Job, 0, len(configs))
 		for _, jcfg := range configs {
-			path := jcfg.TemplateFile
-			output, err := templater.Evaluate(funcMap, jcfg.TemplateData, jcfg.TemplateText, path, "Jenkins Server "+server)
+			output, err := templater.Evaluate(funcMap, jcfg.TemplateData, jcfg.TemplateText, jcfg.TemplateFile, "Jenkins Server "+server)
 			if err != nil {
-				return errors.Wrapf(err, "failed to evaluate template %s", path)
+				return errors.Wrapf(err, "failed to evaluate template %s", jcfg.TemplateFile)
 			}
-			buf.WriteString(indent + "// from template: " + path + "\n")
-			buf.WriteString(indentText(output, indent))
-			buf.WriteString(indent + "\n")
+			outputs[jcfg.Key] = output
+			jobs = append(jobs, format.Job{Key: jcfg.Key, CloneURL: jcfg.CloneURL, TemplateFile: jcfg.TemplateFile, Output: output})
 		}
 
-		err = ioutil.WriteFile(path, []byte(buf.String()), files.DefaultFileWritePermissions)
+		outFiles, err := formatter.Format(server, jobs)
 		if err != nil {
-			return errors.Wrapf(err, "failed to save file %s", path)
+			return errors.Wrapf(err, "failed to format jobs for server %s", server)
+		}
+		for relPath, content := range outFiles {
+			path := filepath.Join(dir, relPath)
+			log.Logger().Infof("creating Jenkins job file %s", path)
+			err = os.MkdirAll(filepath.Dir(path), files.DefaultDirWritePermissions)
+			if err != nil {
+				return errors.Wrapf(err, "failed to create dir for file %s", path)
+			}
+			err = ioutil.WriteFile(path, []byte(content), files.DefaultFileWritePermissions)
+			if err != nil {
+				return errors.Wrapf(err, "failed to save file %s", path)
+			}
+		}
+
+		if o.Apply {
+			err = o.applyJobsToServer(server, outputs)
+			if err != nil {
+				return errors.Wrapf(err, "failed to apply jobs to jenkins server %s", server)
+			}
 		}
 	}
 	return nil
 }
 
-func indentText(text string, indent string) string {
-	lines := strings.Split(text, "\n")
-	return indent + strings.Join(lines, "\n"+indent)
+// applyJobsToServer pushes the rendered Job DSL for each repo straight to the live Jenkins server
+// via its scriptText API, reporting per-repo success/failure instead of waiting for the helmfile roll-out
+func (o *Options) applyJobsToServer(server string, outputs map[string]string) error {
+	serversConfig, err := jenkinsclient.LoadServersConfig(o.JenkinsServersFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load jenkins servers file")
+	}
+	serverConfig := serversConfig.FindServer(server)
+	if serverConfig == nil {
+		log.Logger().Warnf("no jenkins server %s configured in the jenkins-servers.yaml file so cannot apply the generated jobs", info(server))
+		return nil
+	}
+
+	client := jenkinsclient.NewClient(*serverConfig)
+
+	var failed []string
+	for repo, script := range outputs {
+		_, err := client.ApplyScript(script)
+		if err != nil {
+			log.Logger().Warnf("failed to apply job for repository %s on server %s: %s", info(repo), info(server), err.Error())
+			failed = append(failed, repo)
+			continue
+		}
+		log.Logger().Infof("applied job for repository %s on server %s", info(repo), info(server))
+	}
+	if len(failed) > 0 {
+		return errors.Errorf("failed to apply jobs for repositories: %s", strings.Join(failed, ", "))
+	}
+	return nil
 }
 
 func firstNonBlankValue(values ...string) string {
@@ -227,7 +313,176 @@ func firstNonBlankValue(values ...string) string {
 	return ""
 }
 
-func (o *Options) processJenkinsConfig(group *v1alpha1.RepositoryGroup, repo *v1alpha1.Repository, server, jobTemplatePath string) error {
+// fallbackJobTemplate resolves a job template for a repository that has no JenkinsJobTemplate
+// configured at the repository, group or server level. It first tries any matching
+// Spec.JenkinsTemplateFallbacks rule, then walks a language/build-pack aware search path:
+// jenkins/templates/<lang>/<buildpack>.job.gotmpl -> jenkins/templates/<lang>/default.job.gotmpl ->
+// jenkins/templates/default.job.gotmpl, so that repos are no longer silently skipped
+func (o *Options) fallbackJobTemplate(config *v1alpha1.SourceConfig, group *v1alpha1.RepositoryGroup, repo *v1alpha1.Repository) string {
+	fullName := scm.Join(group.Owner, repo.Name)
+	language, buildPack := o.detectLanguageAndBuildPack(repo)
+
+	for i := range config.Spec.JenkinsTemplateFallbacks {
+		rule := &config.Spec.JenkinsTemplateFallbacks[i]
+		if rule.Matches(language, buildPack, fullName) && rule.Template != "" {
+			return rule.Template
+		}
+	}
+
+	var candidates []string
+	if language != "" && buildPack != "" {
+		candidates = append(candidates, filepath.Join("jenkins", "templates", language, buildPack+".job.gotmpl"))
+	}
+	if language != "" {
+		candidates = append(candidates, filepath.Join("jenkins", "templates", language, "default.job.gotmpl"))
+	}
+	candidates = append(candidates, filepath.Join("jenkins", "templates", "default.job.gotmpl"))
+
+	for _, relPath := range candidates {
+		exists, err := files.FileExists(filepath.Join(o.Dir, relPath))
+		if err != nil {
+			log.Logger().Warnf("failed to check if fallback template %s exists: %s", relPath, err.Error())
+			continue
+		}
+		if exists {
+			return relPath
+		}
+	}
+	return ""
+}
+
+// languageMarkerFiles maps the file that identifies a repository's language (and the default
+// JX build pack for that language) when found at the root of a shallow clone, mirroring the
+// detection used by the JX build pack resolver
+var languageMarkerFiles = []struct {
+	file      string
+	language  string
+	buildPack string
+}{
+	{file: "go.mod", language: "go", buildPack: "go"},
+	{file: "pom.xml", language: "java", buildPack: "maven"},
+	{file: "build.gradle", language: "java", buildPack: "gradle"},
+	{file: "package.json", language: "nodejs", buildPack: "nodejs"},
+	{file: "requirements.txt", language: "python", buildPack: "python"},
+	{file: "Gemfile", language: "ruby", buildPack: "ruby"},
+}
+
+// cloneDetectTimeout bounds the shallow clone used to detect a repository's language, so that a
+// slow or unreachable repository (e.g. a private one this command has no credentials for) cannot
+// hang the whole render indefinitely
+const cloneDetectTimeout = 20 * time.Second
+
+// detectLanguageAndBuildPack returns the language/build-pack for repo, preferring the cached
+// Repository.Language/BuildPack fields on the CRD. Falling back to a shallow clone of the
+// repository to inspect its build files is an opt-in, network-dependent behavior gated behind
+// Options.DetectLanguage, since it changes this command from a purely local, fast render into
+// one that performs a git clone per repository. The detected values are cached back onto repo
+// so the clone only happens once per repository
+func (o *Options) detectLanguageAndBuildPack(repo *v1alpha1.Repository) (string, string) {
+	if repo.Language != "" {
+		return repo.Language, repo.BuildPack
+	}
+	if !o.DetectLanguage || repo.HTTPCloneURL == "" {
+		return "", ""
+	}
+
+	language, buildPack, err := cloneAndDetectLanguage(repo.HTTPCloneURL)
+	if err != nil {
+		log.Logger().Warnf("failed to detect language for %s: %s", repo.URL, err.Error())
+		return "", ""
+	}
+
+	repo.Language = language
+	repo.BuildPack = buildPack
+	return language, buildPack
+}
+
+// cloneAndDetectLanguage performs a shallow, time-bounded clone of cloneURL into a temporary
+// directory and inspects its root for well known build files to determine the repository's
+// language and build pack
+func cloneAndDetectLanguage(cloneURL string) (string, string, error) {
+	dir, err := ioutil.TempDir("", "jx-gitops-jenkins-jobs-")
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to create temp dir")
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	ctx, cancel := context.WithTimeout(context.Background(), cloneDetectTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "clone", "--depth", "1", cloneURL, dir) //nolint:gosec
+	err = cmd.Run()
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to shallow clone %s within %s", cloneURL, cloneDetectTimeout)
+	}
+
+	for _, marker := range languageMarkerFiles {
+		exists, err := files.FileExists(filepath.Join(dir, marker.file))
+		if err != nil {
+			return "", "", errors.Wrapf(err, "failed to check for %s", marker.file)
+		}
+		if exists {
+			return marker.language, marker.buildPack, nil
+		}
+	}
+	return "", "", nil
+}
+
+// serverTemplateExtras runs the server's preHooks and loads its valuesFrom files, merging
+// their output into a single map of extra template data available to every job template
+// rendered for that server, so that users can compute dynamic inputs (like a shared
+// library's current Git SHA, or a credential ID) without forking this command
+func (o *Options) serverTemplateExtras(server *v1alpha1.JenkinsServer) (map[string]interface{}, error) {
+	extra := map[string]interface{}{}
+
+	for _, hook := range server.PreHooks {
+		if hook.Cmd == "" {
+			continue
+		}
+		dir := hook.Path
+		if dir == "" {
+			dir = o.Dir
+		}
+		cmd := exec.Command("sh", "-c", hook.Cmd) //nolint:gosec
+		cmd.Dir = dir
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to run preHook %s", hook.Cmd)
+		}
+
+		hookData := map[string]interface{}{}
+		err = yaml.Unmarshal(out, &hookData)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse output of preHook %s as YAML", hook.Cmd)
+		}
+		for k, v := range hookData {
+			extra[k] = v
+		}
+	}
+
+	for _, valuesFile := range server.ValuesFrom {
+		path := valuesFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(o.Dir, path)
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read valuesFrom file %s", path)
+		}
+
+		fileData := map[string]interface{}{}
+		err = yaml.Unmarshal(data, &fileData)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse valuesFrom file %s as YAML", path)
+		}
+		for k, v := range fileData {
+			extra[k] = v
+		}
+	}
+	return extra, nil
+}
+
+func (o *Options) processJenkinsConfig(group *v1alpha1.RepositoryGroup, repo *v1alpha1.Repository, server, jobTemplatePath string, extraData map[string]interface{}) error {
 	if server == "" {
 		log.Logger().Infof("ignoring repository %s as it has no Jenkins server defined", repo.URL)
 		return nil
@@ -236,7 +491,10 @@ func (o *Options) processJenkinsConfig(group *v1alpha1.RepositoryGroup, repo *v1
 		log.Logger().Infof("ignoring repository %s as it has no Jenkins JobTemplate defined at the repository, group or server level", repo.URL)
 		return nil
 	}
-	jobTemplate := filepath.Join(o.Dir, jobTemplatePath)
+	jobTemplate, err := o.Resolver.Resolve(jobTemplatePath, o.SourceConfig.Spec.JenkinsTemplateRepositories)
+	if err != nil {
+		return errors.Wrapf(err, "failed to resolve jobTemplate %s", jobTemplatePath)
+	}
 	exists, err := files.FileExists(jobTemplate)
 	if err != nil {
 		return errors.Wrapf(err, "failed to check if file exists %s", jobTemplate)
@@ -263,10 +521,14 @@ func (o *Options) processJenkinsConfig(group *v1alpha1.RepositoryGroup, repo *v1
 		"URL":          repo.URL,
 		"CloneURL":     repo.HTTPCloneURL,
 	}
+	for k, v := range extraData {
+		templateData[k] = v
+	}
 
 	o.JenkinsServerTemplates[server] = append(o.JenkinsServerTemplates[server], &JenkinsTemplateConfig{
 		Server:       server,
 		Key:          fullName,
+		CloneURL:     repo.HTTPCloneURL,
 		TemplateFile: jobTemplate,
 		TemplateText: string(data),
 		TemplateData: templateData,
@@ -0,0 +1,152 @@
+package jobs
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+	"github.com/jenkins-x/jx-gitops/pkg/jenkinstemplates"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFallbackJobTemplateWalksLanguageSearchPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jenkins-jobs-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	templatePath := filepath.Join(dir, "jenkins", "templates", "go", "default.job.gotmpl")
+	require.NoError(t, os.MkdirAll(filepath.Dir(templatePath), 0o755))
+	require.NoError(t, ioutil.WriteFile(templatePath, []byte("job('{{ .FullName }}') {}"), 0o600))
+
+	o := &Options{Dir: dir}
+	config := &v1alpha1.SourceConfig{}
+	group := &v1alpha1.RepositoryGroup{Owner: "myorg"}
+	repo := &v1alpha1.Repository{Name: "myrepo", Language: "go"}
+
+	got := o.fallbackJobTemplate(config, group, repo)
+	assert.Equal(t, filepath.Join("jenkins", "templates", "go", "default.job.gotmpl"), got)
+}
+
+func TestFallbackJobTemplatePrefersMatchingRule(t *testing.T) {
+	dir := t.TempDir()
+	searchPathTemplate := filepath.Join(dir, "jenkins", "templates", "go", "default.job.gotmpl")
+	require.NoError(t, os.MkdirAll(filepath.Dir(searchPathTemplate), 0o755))
+	require.NoError(t, ioutil.WriteFile(searchPathTemplate, []byte("job('{{ .FullName }}') {}"), 0o600))
+
+	o := &Options{Dir: dir}
+	config := &v1alpha1.SourceConfig{
+		Spec: v1alpha1.SourceConfigSpec{
+			JenkinsTemplateFallbacks: []v1alpha1.TemplateRule{
+				{Language: "go", Template: "jenkins/templates/go-custom.job.gotmpl"},
+			},
+		},
+	}
+	group := &v1alpha1.RepositoryGroup{Owner: "myorg"}
+	repo := &v1alpha1.Repository{Name: "myrepo", Language: "go"}
+
+	got := o.fallbackJobTemplate(config, group, repo)
+	assert.Equal(t, "jenkins/templates/go-custom.job.gotmpl", got)
+}
+
+func TestDetectLanguageAndBuildPackUsesCachedValue(t *testing.T) {
+	o := &Options{DetectLanguage: false}
+	repo := &v1alpha1.Repository{Language: "java", BuildPack: "maven"}
+
+	language, buildPack := o.detectLanguageAndBuildPack(repo)
+	assert.Equal(t, "java", language)
+	assert.Equal(t, "maven", buildPack)
+}
+
+func TestDetectLanguageAndBuildPackSkipsCloneWhenFlagOff(t *testing.T) {
+	o := &Options{DetectLanguage: false}
+	repo := &v1alpha1.Repository{HTTPCloneURL: "https://example.invalid/myorg/myrepo.git"}
+
+	language, buildPack := o.detectLanguageAndBuildPack(repo)
+	assert.Empty(t, language)
+	assert.Empty(t, buildPack)
+}
+
+func TestServerTemplateExtrasMergesPreHooksAndValuesFrom(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jenkins-jobs-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	valuesFile := filepath.Join(dir, "extra-values.yaml")
+	require.NoError(t, ioutil.WriteFile(valuesFile, []byte("fromFile: file-value\n"), 0o600))
+
+	o := &Options{Dir: dir}
+	server := &v1alpha1.JenkinsServer{
+		PreHooks:   []v1alpha1.PreHook{{Cmd: "echo 'fromHook: hook-value'"}},
+		ValuesFrom: []string{"extra-values.yaml"},
+	}
+
+	extra, err := o.serverTemplateExtras(server)
+	require.NoError(t, err)
+	assert.Equal(t, "hook-value", extra["fromHook"])
+	assert.Equal(t, "file-value", extra["fromFile"])
+}
+
+func TestProcessJenkinsConfigSetsCloneURL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jenkins-jobs-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	templatePath := filepath.Join(dir, "job.gotmpl")
+	require.NoError(t, ioutil.WriteFile(templatePath, []byte("job('{{ .FullName }}') {}"), 0o600))
+
+	o := &Options{
+		Dir:                    dir,
+		Resolver:               &jenkinstemplates.Resolver{Dir: dir},
+		JenkinsServerTemplates: map[string][]*JenkinsTemplateConfig{},
+	}
+	group := &v1alpha1.RepositoryGroup{Owner: "myorg"}
+	repo := &v1alpha1.Repository{Name: "myrepo", URL: "https://github.com/myorg/myrepo", HTTPCloneURL: "https://github.com/myorg/myrepo.git"}
+
+	err = o.processJenkinsConfig(group, repo, "myserver", "job.gotmpl", nil)
+	require.NoError(t, err)
+
+	require.Contains(t, o.JenkinsServerTemplates, "myserver")
+	require.Len(t, o.JenkinsServerTemplates["myserver"], 1)
+	cfg := o.JenkinsServerTemplates["myserver"][0]
+	assert.Equal(t, "myorg/myrepo", cfg.Key)
+	assert.Equal(t, "https://github.com/myorg/myrepo.git", cfg.CloneURL)
+}
+
+func TestApplyJobsToServer(t *testing.T) {
+	var receivedScripts []string
+	jenkins := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/crumbIssuer/api/json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		require.NoError(t, r.ParseForm())
+		receivedScripts = append(receivedScripts, r.Form.Get("script"))
+		_, err := w.Write([]byte("Result: ok"))
+		require.NoError(t, err)
+	}))
+	defer jenkins.Close()
+
+	dir := t.TempDir()
+	serversFile := filepath.Join(dir, "jenkins-servers.yaml")
+	require.NoError(t, ioutil.WriteFile(serversFile, []byte("servers:\n- name: myserver\n  url: "+jenkins.URL+"\n"), 0o600))
+
+	o := &Options{JenkinsServersFile: serversFile}
+	err := o.applyJobsToServer("myserver", map[string]string{"myorg/myrepo": "job('myorg/myrepo') {}"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"job('myorg/myrepo') {}"}, receivedScripts)
+}
+
+func TestApplyJobsToServerUnknownServerIsNotAnError(t *testing.T) {
+	dir := t.TempDir()
+	serversFile := filepath.Join(dir, "jenkins-servers.yaml")
+	require.NoError(t, ioutil.WriteFile(serversFile, []byte("servers: []\n"), 0o600))
+
+	o := &Options{JenkinsServersFile: serversFile}
+	err := o.applyJobsToServer("missing", map[string]string{"myorg/myrepo": "job('myorg/myrepo') {}"})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,73 @@
+package jenkinsclient_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/jx-gitops/pkg/jenkinsclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyScriptSendsCrumb(t *testing.T) {
+	var gotCrumbHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/crumbIssuer/api/json":
+			_, err := w.Write([]byte(`{"crumb":"abc123","crumbRequestField":"Jenkins-Crumb"}`))
+			require.NoError(t, err)
+		case "/scriptText":
+			gotCrumbHeader = r.Header.Get("Jenkins-Crumb")
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			assert.Contains(t, string(body), "script=")
+			_, err = w.Write([]byte("Result: hello"))
+			require.NoError(t, err)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := jenkinsclient.NewClient(jenkinsclient.ServerConfig{Name: "test", URL: server.URL})
+	text, err := client.ApplyScript("println 'hello'")
+	require.NoError(t, err)
+	assert.Equal(t, "Result: hello", text)
+	assert.Equal(t, "abc123", gotCrumbHeader)
+}
+
+func TestApplyScriptNoCrumbIssuer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/crumbIssuer/api/json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, err := w.Write([]byte("Result: ok"))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client := jenkinsclient.NewClient(jenkinsclient.ServerConfig{Name: "test", URL: server.URL})
+	text, err := client.ApplyScript("println 'ok'")
+	require.NoError(t, err)
+	assert.Equal(t, "Result: ok", text)
+}
+
+func TestApplyScriptErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/crumbIssuer/api/json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+		_, err := w.Write([]byte("nope"))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client := jenkinsclient.NewClient(jenkinsclient.ServerConfig{Name: "test", URL: server.URL})
+	_, err := client.ApplyScript("println 'ok'")
+	assert.Error(t, err)
+}
@@ -0,0 +1,64 @@
+package jenkinsclient
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yamls"
+	"github.com/pkg/errors"
+)
+
+// ServersFileName the default name of the file used to store the jenkins server credentials
+const ServersFileName = "jenkins-servers.yaml"
+
+// ServersConfig is a kubeconfig-style file of Jenkins servers and their credentials,
+// loaded from ~/.jx/jenkins-servers.yaml
+type ServersConfig struct {
+	Servers []ServerConfig `json:"servers,omitempty"`
+}
+
+// ServerConfig is the URL and credentials used to talk to a single Jenkins server
+type ServerConfig struct {
+	Name     string `json:"name,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Username string `json:"username,omitempty"`
+	Token    string `json:"token,omitempty"`
+}
+
+// FindServer returns the ServerConfig with the given name or nil if it could not be found
+func (c *ServersConfig) FindServer(name string) *ServerConfig {
+	for i := range c.Servers {
+		if c.Servers[i].Name == name {
+			return &c.Servers[i]
+		}
+	}
+	return nil
+}
+
+// LoadServersConfig loads the jenkins-servers.yaml file from the given path.
+// If path is blank it defaults to ~/.jx/jenkins-servers.yaml
+func LoadServersConfig(path string) (*ServersConfig, error) {
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find user home dir")
+		}
+		path = filepath.Join(home, ".jx", ServersFileName)
+	}
+
+	config := &ServersConfig{}
+	exists, err := files.FileExists(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", path)
+	}
+	if !exists {
+		return config, nil
+	}
+
+	err = yamls.LoadFile(path, config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load file %s", path)
+	}
+	return config, nil
+}
@@ -0,0 +1,58 @@
+package jenkinsclient_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx-gitops/pkg/jenkinsclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindServer(t *testing.T) {
+	config := &jenkinsclient.ServersConfig{
+		Servers: []jenkinsclient.ServerConfig{
+			{Name: "cheese", URL: "https://cheese.example.com"},
+			{Name: "wine", URL: "https://wine.example.com"},
+		},
+	}
+
+	server := config.FindServer("wine")
+	require.NotNil(t, server)
+	assert.Equal(t, "https://wine.example.com", server.URL)
+
+	assert.Nil(t, config.FindServer("missing"))
+}
+
+func TestLoadServersConfigMissingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jenkinsclient-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	config, err := jenkinsclient.LoadServersConfig(filepath.Join(dir, "jenkins-servers.yaml"))
+	require.NoError(t, err)
+	assert.Empty(t, config.Servers)
+}
+
+func TestLoadServersConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jenkinsclient-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	path := filepath.Join(dir, "jenkins-servers.yaml")
+	err = ioutil.WriteFile(path, []byte(`servers:
+- name: cheese
+  url: https://cheese.example.com
+  username: jenkins
+  token: secret
+`), 0o600)
+	require.NoError(t, err)
+
+	config, err := jenkinsclient.LoadServersConfig(path)
+	require.NoError(t, err)
+	require.Len(t, config.Servers, 1)
+	assert.Equal(t, "cheese", config.Servers[0].Name)
+	assert.Equal(t, "https://cheese.example.com", config.Servers[0].URL)
+}
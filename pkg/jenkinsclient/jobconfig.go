@@ -0,0 +1,57 @@
+package jenkinsclient
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// jobConfigPath builds the nested /job/<segment>/job/<segment>/.../config.xml path Jenkins
+// uses for jobs organized under a folder per owner, e.g. "owner/repo" -> "/job/owner/job/repo"
+func jobConfigPath(jobName string) string {
+	segments := strings.Split(jobName, "/")
+	escaped := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		escaped = append(escaped, "job", url.PathEscape(segment))
+	}
+	return "/" + strings.Join(escaped, "/") + "/config.xml"
+}
+
+// FetchJobConfigXML fetches the live config.xml for the given job name from the Jenkins server,
+// e.g. to compare against the GitOps rendered version for drift detection
+func (c *Client) FetchJobConfigXML(jobName string) (string, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(c.Server.URL, "/") + jobConfigPath(jobName))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse jenkins URL %s", c.Server.URL)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create request to %s", endpoint.String())
+	}
+	if c.Server.Username != "" {
+		req.SetBasicAuth(c.Server.Username, c.Server.Token)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get job config from %s", endpoint.String())
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read response from %s", endpoint.String())
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if resp.StatusCode >= 300 {
+		return "", errors.Errorf("jenkins server %s returned status %s fetching job %s", c.Server.Name, resp.Status, jobName)
+	}
+	return string(body), nil
+}
@@ -0,0 +1,39 @@
+package jenkinsclient_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/jx-gitops/pkg/jenkinsclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchJobConfigXMLNestedJobPath(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		_, err := w.Write([]byte("<config/>"))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client := jenkinsclient.NewClient(jenkinsclient.ServerConfig{Name: "test", URL: server.URL})
+	xml, err := client.FetchJobConfigXML("myorg/myrepo")
+	require.NoError(t, err)
+	assert.Equal(t, "<config/>", xml)
+	assert.Equal(t, "/job/myorg/job/myrepo/config.xml", gotPath)
+}
+
+func TestFetchJobConfigXMLNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := jenkinsclient.NewClient(jenkinsclient.ServerConfig{Name: "test", URL: server.URL})
+	xml, err := client.FetchJobConfigXML("myorg/missing")
+	require.NoError(t, err)
+	assert.Empty(t, xml)
+}
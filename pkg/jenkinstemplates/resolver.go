@@ -0,0 +1,198 @@
+package jenkinstemplates
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-getter"
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/pkg/errors"
+)
+
+// CacheDirName is the directory under ~/.jx/cache that remote job templates are fetched into
+var CacheDirName = filepath.Join("cache", "jenkins-templates")
+
+// Resolver resolves a job template path which may be a local file, or a remote URI understood
+// by go-getter (e.g. "git::https://github.com/org/templates//path/to/job.gotmpl?ref=v1.2.3"), or
+// the shorthand "<repoName>:<path>" referencing a pre-declared v1alpha1.TemplateRepository, into
+// a local file path ready to be read.
+//
+// NOTE: "oci://" sources are not yet supported - go-getter has no OCI getter registered by
+// default, so Resolve returns a clear error for them rather than letting the fetch fail deep
+// inside go-getter with a confusing "unsupported source" error
+type Resolver struct {
+	// Dir is the local checkout used to resolve relative template paths
+	Dir string
+	// CacheDir is the directory remote templates are downloaded into. Defaults to ~/.jx/cache/jenkins-templates
+	CacheDir string
+}
+
+// NewResolver creates a Resolver rooted at dir, defaulting CacheDir to ~/.jx/cache/jenkins-templates
+func NewResolver(dir string) (*Resolver, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find user home dir")
+	}
+	return &Resolver{
+		Dir:      dir,
+		CacheDir: filepath.Join(home, ".jx", CacheDirName),
+	}, nil
+}
+
+// IsRemote returns true if the given template path refers to a remote source rather than a
+// file relative to the local checkout
+func IsRemote(templatePath string) bool {
+	return strings.Contains(templatePath, "://") || strings.HasPrefix(templatePath, "git::")
+}
+
+// Resolve returns the local file path for the given job template path, downloading and caching
+// it first if it refers to a remote git source. oci:// sources, given directly or through a
+// TemplateRepository URL, return an error
+func (r *Resolver) Resolve(templatePath string, repos []v1alpha1.TemplateRepository) (string, error) {
+	source := templatePath
+
+	if name, subPath, ok := splitRepoShorthand(templatePath); ok {
+		repo := findTemplateRepository(repos, name)
+		if repo == nil {
+			return "", errors.Errorf("no JenkinsTemplateRepositories entry named %q found for template %q", name, templatePath)
+		}
+		if strings.HasPrefix(repo.URL, "oci://") {
+			return "", errors.Errorf("oci:// job template sources are not yet supported (JenkinsTemplateRepositories entry %q has URL %q); use a git:: source instead", name, repo.URL)
+		}
+		ref := repo.Ref
+		if ref == "" {
+			ref = "main"
+		}
+		source = fmt.Sprintf("git::%s//%s?ref=%s", repo.URL, subPath, ref)
+	}
+
+	if !IsRemote(source) {
+		return filepath.Join(r.Dir, templatePath), nil
+	}
+
+	if strings.HasPrefix(source, "oci://") {
+		return "", errors.Errorf("oci:// job template sources are not yet supported (got %q); use a git:: source instead", source)
+	}
+
+	return r.fetch(source)
+}
+
+// fetch downloads a remote template source into a directory under CacheDir named by the
+// SHA-256 of the source string, and returns the local path to the downloaded template file.
+//
+// The cache is keyed by the source string, so a pinned ref (a tag or commit SHA) is safe to
+// reuse forever. A moving ref like the "main" branch used by default is not: re-fetch every
+// time so that upstream template changes are picked up instead of being cached indefinitely
+func (r *Resolver) fetch(source string) (string, error) {
+	dest := filepath.Join(r.CacheDir, checksum(source))
+
+	exists, err := files.DirExists(dest)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to check if cache dir exists %s", dest)
+	}
+	if !exists || !refIsPinned(source) {
+		client := &getter.Client{
+			Src:  source,
+			Dst:  dest,
+			Pwd:  r.Dir,
+			Mode: getter.ClientModeAny,
+		}
+		err = client.Get()
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to fetch template source %s", source)
+		}
+	}
+
+	info, err := os.Stat(dest)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to stat downloaded template %s", dest)
+	}
+	if !info.IsDir() {
+		return dest, nil
+	}
+
+	// go-getter downloaded a directory (the source's "//subdir" pointed at a directory rather
+	// than a single file) - resolve the actual template file inside it using the subdir's last
+	// path segment, e.g. ".../templates//path/to/job.gotmpl" -> <dest>/job.gotmpl
+	subPath := subdirFromSource(source)
+	if subPath != "" {
+		candidate := filepath.Join(dest, filepath.Base(subPath))
+		candidateExists, err := files.FileExists(candidate)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to check if file exists %s", candidate)
+		}
+		if candidateExists {
+			return candidate, nil
+		}
+	}
+	return "", errors.Errorf("resolved template source %s is a directory %s, expected a single template file", source, dest)
+}
+
+// subdirFromSource returns the "//subdir" portion of a go-getter source string, if any.
+// It first skips past the "://" scheme separator so it isn't mistaken for the subdir delimiter
+func subdirFromSource(source string) string {
+	rest := source
+	if idx := strings.Index(rest, "://"); idx >= 0 {
+		rest = rest[idx+len("://"):]
+	}
+	idx := strings.Index(rest, "//")
+	if idx < 0 {
+		return ""
+	}
+	rest = rest[idx+2:]
+	if q := strings.Index(rest, "?"); q >= 0 {
+		rest = rest[:q]
+	}
+	return rest
+}
+
+// refIsPinned returns true if the source's "?ref=" query parameter looks like an immutable
+// tag or commit SHA rather than a moving branch name such as "main", "master" or "HEAD"
+func refIsPinned(source string) bool {
+	idx := strings.Index(source, "ref=")
+	if idx < 0 {
+		return false
+	}
+	ref := source[idx+len("ref="):]
+	if amp := strings.Index(ref, "&"); amp >= 0 {
+		ref = ref[:amp]
+	}
+	switch ref {
+	case "", "main", "master", "HEAD":
+		return false
+	default:
+		return true
+	}
+}
+
+func checksum(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:])
+}
+
+// splitRepoShorthand splits "<repoName>:<path>" into its repo name and path, returning ok=false
+// if templatePath is not using the shorthand form (e.g. it's a plain local path or full URI)
+func splitRepoShorthand(templatePath string) (name, subPath string, ok bool) {
+	if IsRemote(templatePath) {
+		return "", "", false
+	}
+	idx := strings.Index(templatePath, ":")
+	if idx <= 0 {
+		return "", "", false
+	}
+	return templatePath[:idx], templatePath[idx+1:], true
+}
+
+func findTemplateRepository(repos []v1alpha1.TemplateRepository, name string) *v1alpha1.TemplateRepository {
+	for i := range repos {
+		if repos[i].Name == name {
+			return &repos[i]
+		}
+	}
+	return nil
+}
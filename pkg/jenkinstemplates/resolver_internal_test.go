@@ -0,0 +1,20 @@
+package jenkinstemplates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefIsPinned(t *testing.T) {
+	assert.False(t, refIsPinned("git::https://github.com/org/templates//job.gotmpl?ref=main"), "main is a moving branch, not a pin")
+	assert.False(t, refIsPinned("git::https://github.com/org/templates//job.gotmpl?ref=master"))
+	assert.False(t, refIsPinned("git::https://github.com/org/templates//job.gotmpl"), "no ref at all is the least pinned case")
+	assert.True(t, refIsPinned("git::https://github.com/org/templates//job.gotmpl?ref=v1.2.3"))
+	assert.True(t, refIsPinned("git::https://github.com/org/templates//job.gotmpl?ref=abc1234"))
+}
+
+func TestSubdirFromSource(t *testing.T) {
+	assert.Equal(t, "path/to/job.gotmpl", subdirFromSource("git::https://github.com/org/templates//path/to/job.gotmpl?ref=v1"))
+	assert.Equal(t, "", subdirFromSource("git::https://github.com/org/templates"))
+}
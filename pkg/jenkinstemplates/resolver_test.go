@@ -0,0 +1,49 @@
+package jenkinstemplates_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/jx-gitops/pkg/apis/gitops/v1alpha1"
+	"github.com/jenkins-x/jx-gitops/pkg/jenkinstemplates"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRemote(t *testing.T) {
+	assert.True(t, jenkinstemplates.IsRemote("git::https://github.com/org/templates//job.gotmpl?ref=v1.2.3"))
+	assert.True(t, jenkinstemplates.IsRemote("oci://ghcr.io/org/jenkins-templates:v1"))
+	assert.False(t, jenkinstemplates.IsRemote("jenkins/templates/default.job.gotmpl"))
+}
+
+func TestResolveLocalPath(t *testing.T) {
+	resolver := &jenkinstemplates.Resolver{Dir: "/repo", CacheDir: "/cache"}
+
+	path, err := resolver.Resolve("jenkins/templates/default.job.gotmpl", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join("/repo", "jenkins/templates/default.job.gotmpl"), path)
+}
+
+func TestResolveUnknownRepoShorthand(t *testing.T) {
+	resolver := &jenkinstemplates.Resolver{Dir: "/repo", CacheDir: "/cache"}
+
+	_, err := resolver.Resolve("acme:job.gotmpl", []v1alpha1.TemplateRepository{
+		{Name: "other", URL: "https://github.com/org/templates"},
+	})
+	assert.Error(t, err)
+}
+
+func TestResolveOCINotSupported(t *testing.T) {
+	resolver := &jenkinstemplates.Resolver{Dir: "/repo", CacheDir: "/cache"}
+
+	_, err := resolver.Resolve("oci://ghcr.io/org/jenkins-templates:v1", nil)
+	assert.Error(t, err)
+}
+
+func TestResolveOCIRepoShorthandNotSupported(t *testing.T) {
+	resolver := &jenkinstemplates.Resolver{Dir: "/repo", CacheDir: "/cache"}
+
+	_, err := resolver.Resolve("acme:job.gotmpl", []v1alpha1.TemplateRepository{
+		{Name: "acme", URL: "oci://ghcr.io/org/jenkins-templates:v1"},
+	})
+	assert.Error(t, err, "expected the oci:// URL on the shorthand's repo entry to be rejected before it's wrapped in git::")
+}